@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+)
+
+// ExceptionsConfig configures callers that should bypass rate limiting
+// entirely, such as internal services or trusted API consumers. It's the
+// serializable form, suitable for loading from JSON or YAML; call
+// NewExceptions to get a checker ready to use against requests.
+type ExceptionsConfig struct {
+	// AllowedCIDRs are IP ranges that skip rate limiting, e.g. "10.0.0.0/8".
+	AllowedCIDRs []string `json:"allowed_cidrs" yaml:"allowed_cidrs"`
+
+	// APIKeys are values that, when found in APIKeyHeader, skip rate
+	// limiting.
+	APIKeys []string `json:"api_keys" yaml:"api_keys"`
+
+	// APIKeyHeader is the header inspected for a bypass API key. Defaults to
+	// "X-API-Key" when empty.
+	APIKeyHeader string `json:"api_key_header" yaml:"api_key_header"`
+}
+
+// Exceptions is the parsed, ready-to-check form of an ExceptionsConfig.
+type Exceptions struct {
+	prefixes     []netip.Prefix
+	apiKeys      map[string]struct{}
+	apiKeyHeader string
+}
+
+// NewExceptions parses cfg into an Exceptions checker. It returns an error if
+// any entry in AllowedCIDRs fails to parse.
+func NewExceptions(cfg ExceptionsConfig) (*Exceptions, error) {
+	prefixes := make([]netip.Prefix, 0, len(cfg.AllowedCIDRs))
+	for _, cidr := range cfg.AllowedCIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("exceptions: invalid CIDR %q: %w", cidr, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	apiKeys := make(map[string]struct{}, len(cfg.APIKeys))
+	for _, key := range cfg.APIKeys {
+		apiKeys[key] = struct{}{}
+	}
+
+	header := cfg.APIKeyHeader
+	if header == "" {
+		header = "X-API-Key"
+	}
+
+	return &Exceptions{
+		prefixes:     prefixes,
+		apiKeys:      apiKeys,
+		apiKeyHeader: header,
+	}, nil
+}
+
+// Allows reports whether the request should bypass rate limiting, either
+// because ip falls within an allowed CIDR or because the request carries a
+// recognized API key.
+func (e *Exceptions) Allows(r *http.Request, ip string) bool {
+	if e == nil {
+		return false
+	}
+
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		for _, prefix := range e.prefixes {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+	}
+
+	if key := r.Header.Get(e.apiKeyHeader); key != "" {
+		if _, ok := e.apiKeys[key]; ok {
+			return true
+		}
+	}
+
+	return false
+}
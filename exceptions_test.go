@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewExceptionsRejectsInvalidCIDR(t *testing.T) {
+	_, err := NewExceptions(ExceptionsConfig{AllowedCIDRs: []string{"not-a-cidr"}})
+	if err == nil {
+		t.Fatal("expected an error for an invalid CIDR")
+	}
+}
+
+func TestExceptionsAllowsCIDRMatch(t *testing.T) {
+	exceptions, err := NewExceptions(ExceptionsConfig{AllowedCIDRs: []string{"10.0.0.0/8"}})
+	if err != nil {
+		t.Fatalf("NewExceptions returned error: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/data", nil)
+
+	if !exceptions.Allows(r, "10.1.2.3") {
+		t.Error("expected an IP inside the allowed CIDR to bypass the limiter")
+	}
+	if exceptions.Allows(r, "192.168.1.1") {
+		t.Error("expected an IP outside the allowed CIDR not to bypass the limiter")
+	}
+}
+
+func TestExceptionsAllowsAPIKeyMatch(t *testing.T) {
+	exceptions, err := NewExceptions(ExceptionsConfig{APIKeys: []string{"secret-key"}})
+	if err != nil {
+		t.Fatalf("NewExceptions returned error: %v", err)
+	}
+
+	allowed := httptest.NewRequest(http.MethodGet, "/api/data", nil)
+	allowed.Header.Set("X-API-Key", "secret-key")
+	if !exceptions.Allows(allowed, "203.0.113.1") {
+		t.Error("expected a request with a recognized API key to bypass the limiter")
+	}
+
+	denied := httptest.NewRequest(http.MethodGet, "/api/data", nil)
+	denied.Header.Set("X-API-Key", "wrong-key")
+	if exceptions.Allows(denied, "203.0.113.1") {
+		t.Error("expected a request with an unrecognized API key not to bypass the limiter")
+	}
+}
+
+func TestExceptionsUsesConfiguredAPIKeyHeader(t *testing.T) {
+	exceptions, err := NewExceptions(ExceptionsConfig{
+		APIKeys:      []string{"secret-key"},
+		APIKeyHeader: "Authorization",
+	})
+	if err != nil {
+		t.Fatalf("NewExceptions returned error: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/data", nil)
+	r.Header.Set("Authorization", "secret-key")
+
+	if !exceptions.Allows(r, "203.0.113.1") {
+		t.Error("expected the configured header to be checked for the API key")
+	}
+}
+
+func TestNilExceptionsAllowsNothing(t *testing.T) {
+	var exceptions *Exceptions
+
+	r := httptest.NewRequest(http.MethodGet, "/api/data", nil)
+	r.Header.Set("X-API-Key", "anything")
+
+	if exceptions.Allows(r, "10.0.0.1") {
+		t.Error("expected a nil Exceptions to never bypass the limiter")
+	}
+}
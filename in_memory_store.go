@@ -0,0 +1,109 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// InMemoryStore is the default Store backend: each key gets its own
+// token-bucket limiter living in a process-local map, with no coordination
+// across instances. This is the same per-IP token-bucket approach
+// RateLimiter used internally before it was refactored to go through Store;
+// keeping it token-bucket based (rather than a plain fixed-window counter)
+// avoids reintroducing the boundary-burst problem chunk0-1 fixed.
+type InMemoryStore struct {
+	Mutex sync.Mutex
+
+	limiters map[string]*ipLimiter
+
+	rate  rate.Limit
+	burst int
+	ttl   time.Duration
+}
+
+// NewInMemoryStore creates an InMemoryStore enforcing maxRequests per
+// windowDuration, translated into an equivalent token-bucket rate and burst.
+func NewInMemoryStore(maxRequests int, windowDuration time.Duration) *InMemoryStore {
+	return NewInMemoryStoreWithTTL(maxRequests, windowDuration, defaultTTL)
+}
+
+// NewInMemoryStoreWithTTL is like NewInMemoryStore but lets callers configure
+// how long an idle key is tracked before the sweeper evicts it.
+func NewInMemoryStoreWithTTL(maxRequests int, windowDuration, ttl time.Duration) *InMemoryStore {
+	s := &InMemoryStore{
+		limiters: make(map[string]*ipLimiter),
+		rate:     rate.Limit(float64(maxRequests) / windowDuration.Seconds()),
+		burst:    maxRequests,
+		ttl:      ttl,
+	}
+
+	s.startSweeper()
+
+	return s
+}
+
+// entry returns the ipLimiter for key, creating one if this is the first
+// time it's been seen, and refreshes its lastSeen timestamp.
+func (s *InMemoryStore) entry(key string) *ipLimiter {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	e, ok := s.limiters[key]
+	if !ok {
+		e = &ipLimiter{limiter: rate.NewLimiter(s.rate, s.burst)}
+		s.limiters[key] = e
+	}
+	e.lastSeen = time.Now()
+
+	return e
+}
+
+// Increment consumes one token from key's bucket. window is accepted for
+// Store interface compatibility but ignored: InMemoryStore's rate is fixed
+// at construction, unlike RedisStore which can be shared across differently
+// configured callers. The returned count is how many of burst's tokens are
+// currently spent, so a caller comparing it against the configured
+// maxRequests sees the same pass/fail decision a direct limiter.Allow()
+// would have made; once the bucket is exhausted, count is reported one past
+// burst so callers stay consistent even though the bucket itself won't go
+// negative.
+func (s *InMemoryStore) Increment(key string, _ time.Duration) (int, time.Time, error) {
+	e := s.entry(key)
+
+	allowed := e.limiter.Allow()
+	tokens := e.limiter.Tokens()
+
+	used := s.burst - int(tokens)
+	if !allowed {
+		used = s.burst + 1
+	}
+
+	resetAt := time.Now()
+	if needed := float64(s.burst) - tokens; needed > 0 {
+		resetAt = resetAt.Add(time.Duration(needed / float64(s.rate) * float64(time.Second)))
+	}
+
+	return used, resetAt, nil
+}
+
+// startSweeper runs in the background, evicting keys that have been idle
+// longer than ttl so the map doesn't grow unbounded.
+func (s *InMemoryStore) startSweeper() {
+	ticker := time.NewTicker(sweepInterval)
+
+	go func() {
+		for range ticker.C {
+			cutoff := time.Now().Add(-s.ttl)
+
+			s.Mutex.Lock()
+			for key, e := range s.limiters {
+				if e.lastSeen.Before(cutoff) {
+					delete(s.limiters, key)
+				}
+			}
+			s.Mutex.Unlock()
+		}
+	}()
+}
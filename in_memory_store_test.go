@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInMemoryStoreIncrement(t *testing.T) {
+	store := NewInMemoryStore(3, time.Minute)
+
+	for i := 1; i <= 3; i++ {
+		count, resetAt, err := store.Increment("1.2.3.4", time.Minute)
+		if err != nil {
+			t.Fatalf("Increment returned error: %v", err)
+		}
+		if count != i {
+			t.Errorf("expected count %d, got %d", i, count)
+		}
+		if resetAt.Before(time.Now()) {
+			t.Errorf("expected resetAt in the future, got %v", resetAt)
+		}
+	}
+
+	count, _, err := store.Increment("1.2.3.4", time.Minute)
+	if err != nil {
+		t.Fatalf("Increment returned error: %v", err)
+	}
+	if count <= 3 {
+		t.Errorf("expected 4th increment to read as over the limit, got count %d", count)
+	}
+}
+
+func TestInMemoryStoreIsolatesKeys(t *testing.T) {
+	store := NewInMemoryStore(1, time.Minute)
+
+	count, _, err := store.Increment("1.1.1.1", time.Minute)
+	if err != nil {
+		t.Fatalf("Increment returned error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected count 1 for first key, got %d", count)
+	}
+
+	count, _, err = store.Increment("2.2.2.2", time.Minute)
+	if err != nil {
+		t.Fatalf("Increment returned error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected count 1 for second key, got %d", count)
+	}
+}
+
+func TestRateLimiterEnforcesLimit(t *testing.T) {
+	rl := NewRateLimiter(3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if !rl.AllowRequest("9.9.9.9") {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+
+	if rl.AllowRequest("9.9.9.9") {
+		t.Error("expected 4th request to be denied")
+	}
+
+	if remaining := rl.Remaining("9.9.9.9"); remaining != 0 {
+		t.Errorf("expected 0 remaining after exhausting the limit, got %d", remaining)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/netip"
 	"time"
 )
 
@@ -21,10 +22,23 @@ func main() {
 	// Initialize the rate limiter with 5 requests per minute
 	// This creates a new RateLimiter instance and starts the background reset goroutine
 	rateLimiter := NewRateLimiter(maxRequestsPerMinute, rateLimitWindow)
-	
+
+	// A single default policy applies the same limiter to every route; add
+	// entries to Policies to give specific method/path combinations their
+	// own Limiter - a *RateLimiter, a *SlidingWindowLimiter, or any other
+	// type satisfying Limiter.
+	policies := &PolicySet{Default: rateLimiter}
+
+	// No proxies are trusted by default, so X-Forwarded-For/X-Real-IP are
+	// ignored unless the server sits behind a known reverse proxy; add its
+	// CIDR here, e.g. []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}.
+	var trustedProxies []netip.Prefix
+
 	// Create the middleware function that will wrap our handlers
 	// This middleware will check rate limits before allowing requests through
-	rateLimitMiddleware := RateLimitMiddleware(rateLimiter)
+	// No exceptions are configured by default; pass an *Exceptions built via
+	// NewExceptions to let trusted CIDRs or API keys bypass the limiter.
+	rateLimitMiddleware := RateLimitMiddleware(policies, nil, trustedProxies)
 	
 	// Register the /api/data endpoint with rate limiting middleware
 	// The middleware runs first, then if allowed, the handler executes
@@ -2,69 +2,132 @@ package main
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
+	"net/netip"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// RateLimitMiddleware creates a middleware function that applies rate limiting
+// RateLimitMiddleware creates a middleware function that applies rate limiting.
+// policies resolves each request to the RateLimiter that should govern it
+// (see PolicySet); exceptions may be nil, in which case no caller bypasses
+// the limiter. trustedProxies lists the CIDRs allowed to set forwarding
+// headers; requests from anywhere else have those headers ignored.
 // This middleware runs before the actual handler and checks if the request should be allowed
-func RateLimitMiddleware(limiter *RateLimiter) func(http.HandlerFunc) http.HandlerFunc {
+func RateLimitMiddleware(policies *PolicySet, exceptions *Exceptions, trustedProxies []netip.Prefix) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			// Extract the client's IP address from the request
 			// This handles various proxy scenarios (X-Forwarded-For, X-Real-IP)
-			ip := getClientIP(r)
-			
+			ip := getClientIP(r, trustedProxies)
+
+			// Trusted callers (allow-listed CIDRs or a valid bypass API key)
+			// skip rate limiting entirely, including the response headers.
+			if exceptions.Allows(r, ip) {
+				next(w, r)
+				return
+			}
+
+			limiter := policies.Resolve(r.Method, r.URL.Path)
+			if limiter == nil {
+				// No policy matched and no default is configured - nothing
+				// to enforce, so let the request through unmodified.
+				next(w, r)
+				return
+			}
+
 			// Check if the request should be allowed based on rate limiting
-			if !limiter.AllowRequest(ip) {
+			allowed := limiter.AllowRequest(ip)
+			resetAt := limiter.ResetAt(ip)
+
+			// Standard rate-limit headers, set on every response regardless of outcome
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limiter.Limit()))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(limiter.Remaining(ip)))
+			w.Header().Set("X-RateLimit-Reset", resetAt.UTC().Format(time.RFC3339))
+
+			if !allowed {
 				// Rate limit exceeded - return 429 Too Many Requests
+				retryAfter := int(time.Until(resetAt).Seconds())
+				if retryAfter < 0 {
+					retryAfter = 0
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusTooManyRequests)
-				
+
 				// Return JSON error response as specified
 				errorResponse := map[string]string{
 					"error": "Rate limit exceeded",
 				}
-				
+
 				json.NewEncoder(w).Encode(errorResponse)
 				return
 			}
-			
+
 			// Request is allowed - proceed to the next handler
 			next(w, r)
 		}
 	}
 }
 
-// getClientIP extracts the client's IP address from the HTTP request
-// It checks various headers that proxies/load balancers might set
-// Falls back to RemoteAddr if no headers are present
-func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header (set by proxies/load balancers)
-	// This header can contain multiple IPs, so we take the first one
-	forwarded := r.Header.Get("X-Forwarded-For")
-	if forwarded != "" {
-		// X-Forwarded-For can contain multiple IPs separated by commas
-		// The first IP is usually the original client IP
-		ips := strings.Split(forwarded, ",")
-		if len(ips) > 0 {
-			return strings.TrimSpace(ips[0])
+// getClientIP extracts the client's IP address from the HTTP request.
+// Forwarding headers (X-Forwarded-For, X-Real-IP) are only honored when
+// RemoteAddr itself is a trusted proxy; otherwise a client could simply set
+// those headers to spoof its IP and evade the limiter. When walking
+// X-Forwarded-For, trailing entries that are themselves trusted proxies are
+// stripped to find the real client.
+func getClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	remoteIP := stripPort(r.RemoteAddr)
+
+	if !isTrustedProxy(remoteIP, trustedProxies) {
+		return remoteIP
+	}
+
+	// X-Forwarded-For can contain multiple IPs separated by commas, ordered
+	// client -> proxy1 -> proxy2 -> ... -> this server. Walk from the end,
+	// skipping addresses that are themselves trusted proxies, until we find
+	// the first untrusted (i.e. real client) address.
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		hops := strings.Split(forwarded, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if !isTrustedProxy(hop, trustedProxies) {
+				return hop
+			}
 		}
 	}
-	
+
 	// Check X-Real-IP header (alternative header used by some proxies)
-	realIP := r.Header.Get("X-Real-IP")
-	if realIP != "" {
+	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
 		return strings.TrimSpace(realIP)
 	}
-	
-	// Fall back to RemoteAddr (direct connection)
-	// RemoteAddr includes port, so we extract just the IP
-	ip := r.RemoteAddr
-	if idx := strings.LastIndex(ip, ":"); idx != -1 {
-		ip = ip[:idx]
+
+	return remoteIP
+}
+
+// stripPort removes an optional port from addr, using net.SplitHostPort so
+// bracketed IPv6 addresses like "[::1]:1234" are handled correctly.
+func stripPort(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
 	}
-	
-	return ip
+	return addr
 }
 
+// isTrustedProxy reports whether ip falls within one of the trusted prefixes.
+func isTrustedProxy(ip string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
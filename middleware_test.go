@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestGetClientIPUsesRemoteAddrWhenNotTrusted(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/data", nil)
+	r.RemoteAddr = "203.0.113.5:4321"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	if ip := getClientIP(r, nil); ip != "203.0.113.5" {
+		t.Errorf("expected untrusted RemoteAddr to win, got %q", ip)
+	}
+}
+
+func TestGetClientIPStripsIPv6Port(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/data", nil)
+	r.RemoteAddr = "[::1]:4321"
+
+	if ip := getClientIP(r, nil); ip != "::1" {
+		t.Errorf("expected bracketed IPv6 RemoteAddr to be stripped of its port, got %q", ip)
+	}
+}
+
+func TestGetClientIPWalksBackTrustedHops(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/data", nil)
+	r.RemoteAddr = "10.0.0.1:4321"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1, 10.0.0.2, 10.0.0.1")
+
+	if ip := getClientIP(r, trusted); ip != "198.51.100.1" {
+		t.Errorf("expected the walk-back to find the real client past trusted hops, got %q", ip)
+	}
+}
+
+func TestGetClientIPStopsAtFirstUntrustedHop(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/data", nil)
+	r.RemoteAddr = "10.0.0.1:4321"
+	// A spoofed address inserted before the real client should be ignored;
+	// the walk-back must stop at the first untrusted hop from the end.
+	r.Header.Set("X-Forwarded-For", "6.6.6.6, 198.51.100.1, 10.0.0.1")
+
+	if ip := getClientIP(r, trusted); ip != "198.51.100.1" {
+		t.Errorf("expected the nearest untrusted hop to win, got %q", ip)
+	}
+}
+
+func TestGetClientIPFallsBackToXRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/data", nil)
+	r.RemoteAddr = "10.0.0.1:4321"
+	r.Header.Set("X-Real-IP", "198.51.100.9")
+
+	if ip := getClientIP(r, trusted); ip != "198.51.100.9" {
+		t.Errorf("expected X-Real-IP to be used when no X-Forwarded-For is set, got %q", ip)
+	}
+}
+
+func TestIsTrustedProxyBoundaries(t *testing.T) {
+	prefixes := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	if !isTrustedProxy("10.255.255.255", prefixes) {
+		t.Error("expected the top of the prefix range to be trusted")
+	}
+	if isTrustedProxy("11.0.0.0", prefixes) {
+		t.Error("expected just outside the prefix range not to be trusted")
+	}
+	if isTrustedProxy("not-an-ip", prefixes) {
+		t.Error("expected an unparsable address not to be trusted")
+	}
+}
+
+func TestRateLimitMiddlewareBypassTakesPrecedenceOverLimiter(t *testing.T) {
+	rl := NewRateLimiter(1, time.Minute)
+	policies := &PolicySet{Default: rl}
+	exceptions, err := NewExceptions(ExceptionsConfig{AllowedCIDRs: []string{"203.0.113.0/24"}})
+	if err != nil {
+		t.Fatalf("NewExceptions returned error: %v", err)
+	}
+
+	handler := RateLimitMiddleware(policies, exceptions, nil)(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/data", nil)
+		r.RemoteAddr = "203.0.113.1:1111"
+		return r
+	}
+
+	// Exhaust the limit for this IP through a non-exempt request first.
+	handler(httptest.NewRecorder(), newRequest())
+
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		handler(w, newRequest())
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected request %d from an allow-listed IP to bypass the limiter, got status %d", i+1, w.Code)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "" {
+			t.Error("expected a bypassed request not to receive rate-limit headers")
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+// Limiter is the behavior a Policy needs from whatever enforces its limit.
+// Both RateLimiter and SlidingWindowLimiter satisfy it, so either can be
+// plugged into a Policy or PolicySet.Default.
+type Limiter interface {
+	AllowRequest(ip string) bool
+	Limit() int
+	Remaining(ip string) int
+	ResetAt(ip string) time.Time
+}
+
+// Policy pairs a route matcher with the Limiter that should govern requests
+// matching it.
+type Policy struct {
+	// Method is the HTTP method to match, e.g. "POST". Empty or "*" matches
+	// any method.
+	Method string
+
+	// PathPattern is a glob-style pattern matched against the request path,
+	// e.g. "/api/*" (see path.Match for supported syntax).
+	PathPattern string
+
+	// Limiter is applied when this policy matches.
+	Limiter Limiter
+}
+
+// PolicySet resolves an incoming request to the Limiter that should apply to
+// it, falling back to Default when no policy matches.
+type PolicySet struct {
+	Policies []Policy
+	Default  Limiter
+}
+
+// Resolve returns the Limiter that applies to the given method and path,
+// checking policies in order and falling back to ps.Default.
+func (ps *PolicySet) Resolve(method, requestPath string) Limiter {
+	for _, p := range ps.Policies {
+		if p.Method != "" && p.Method != "*" && !strings.EqualFold(p.Method, method) {
+			continue
+		}
+		if matched, err := path.Match(p.PathPattern, requestPath); err == nil && matched {
+			return p.Limiter
+		}
+	}
+
+	return ps.Default
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// stubLimiter is a minimal Limiter used only so tests can tell which policy
+// matched by identity.
+type stubLimiter struct{}
+
+func (s *stubLimiter) AllowRequest(string) bool { return true }
+func (s *stubLimiter) Limit() int               { return 0 }
+func (s *stubLimiter) Remaining(string) int      { return 0 }
+func (s *stubLimiter) ResetAt(string) time.Time  { return time.Time{} }
+
+func TestPolicySetResolveMatchesPathGlob(t *testing.T) {
+	writes := &stubLimiter{}
+	ps := &PolicySet{
+		Policies: []Policy{
+			{Method: "POST", PathPattern: "/api/*", Limiter: writes},
+		},
+	}
+
+	if got := ps.Resolve("POST", "/api/data"); got != Limiter(writes) {
+		t.Errorf("expected glob-matching POST /api/data to resolve to the writes policy, got %v", got)
+	}
+}
+
+func TestPolicySetResolveFallsThroughOnMethodMismatch(t *testing.T) {
+	writes := &stubLimiter{}
+	def := &stubLimiter{}
+	ps := &PolicySet{
+		Policies: []Policy{
+			{Method: "POST", PathPattern: "/api/*", Limiter: writes},
+		},
+		Default: def,
+	}
+
+	if got := ps.Resolve("GET", "/api/data"); got != Limiter(def) {
+		t.Errorf("expected GET to fall through a POST-only policy to Default, got %v", got)
+	}
+}
+
+func TestPolicySetResolveChecksPoliciesInOrder(t *testing.T) {
+	first := &stubLimiter{}
+	second := &stubLimiter{}
+	ps := &PolicySet{
+		Policies: []Policy{
+			{Method: "GET", PathPattern: "/api/*", Limiter: first},
+			{Method: "GET", PathPattern: "/api/data", Limiter: second},
+		},
+	}
+
+	if got := ps.Resolve("GET", "/api/data"); got != Limiter(first) {
+		t.Errorf("expected the first matching policy to win over a later, more specific one, got %v", got)
+	}
+}
+
+func TestPolicySetResolveFallsBackToDefault(t *testing.T) {
+	def := &stubLimiter{}
+	ps := &PolicySet{Default: def}
+
+	if got := ps.Resolve("GET", "/anything"); got != Limiter(def) {
+		t.Errorf("expected no matching policy to fall back to Default, got %v", got)
+	}
+
+	var empty PolicySet
+	if got := empty.Resolve("GET", "/anything"); got != nil {
+		t.Errorf("expected an empty PolicySet with no Default to resolve to nil, got %v", got)
+	}
+}
+
+func TestPolicySetResolveMethodWildcardAndCaseInsensitivity(t *testing.T) {
+	anyMethod := &stubLimiter{}
+	ps := &PolicySet{
+		Policies: []Policy{
+			{Method: "*", PathPattern: "/api/*", Limiter: anyMethod},
+		},
+	}
+	if got := ps.Resolve("DELETE", "/api/data"); got != Limiter(anyMethod) {
+		t.Errorf("expected a wildcard method to match any method, got %v", got)
+	}
+
+	lowercase := &stubLimiter{}
+	ps2 := &PolicySet{
+		Policies: []Policy{
+			{Method: "post", PathPattern: "/api/*", Limiter: lowercase},
+		},
+	}
+	if got := ps2.Resolve("POST", "/api/data"); got != Limiter(lowercase) {
+		t.Errorf("expected method matching to be case-insensitive, got %v", got)
+	}
+}
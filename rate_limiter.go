@@ -3,98 +3,129 @@ package main
 import (
 	"sync"
 	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultTTL is how long an idle IP entry is kept before the sweeper
+	// evicts it.
+	defaultTTL = 10 * time.Minute
+
+	// sweepInterval controls how often the background sweeper scans for
+	// stale entries.
+	sweepInterval = 1 * time.Minute
 )
 
-// RateLimiter manages rate limiting for IP addresses
-// It tracks the number of requests per IP and enforces a limit
+// ipLimiter pairs a token-bucket limiter with the last time it was used, so
+// a sweeper can evict IPs that have gone idle.
+type ipLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter manages per-IP rate limiting by delegating counting to a
+// Store, so the same logic enforces limits whether that store is an
+// in-memory map (the default) or a shared backend like Redis.
 type RateLimiter struct {
-	// Requests maps IP addresses to their request count in the current window
-	Requests map[string]int
-	
-	// Mutex ensures thread-safe access to the Requests map
-	// This prevents race conditions when multiple goroutines access the map concurrently
+	// Mutex guards windowState.
 	Mutex sync.Mutex
-	
-	// MaxRequests is the maximum number of requests allowed per time window
-	MaxRequests int
-	
-	// WindowDuration is the time window for rate limiting (e.g., 1 minute)
-	WindowDuration time.Duration
+
+	// store records one request per AllowRequest call and reports back the
+	// resulting count for the current window.
+	store Store
+
+	// rate and burst are the token-bucket parameters; burst doubles as
+	// maxRequests for comparing store-reported counts.
+	rate  rate.Limit
+	burst int
+
+	// windowDuration is the window handed to store.Increment.
+	windowDuration time.Duration
+
+	// windowState tracks each IP's last known count/reset so Remaining and
+	// ResetAt can report on it without a second round trip to the store.
+	windowState map[string]windowState
+}
+
+// windowState is the last known store-reported state for one IP.
+type windowState struct {
+	count   int
+	resetAt time.Time
 }
 
-// NewRateLimiter creates a new RateLimiter instance
+// NewRateLimiter creates a new RateLimiter instance backed by an
+// InMemoryStore.
 // maxRequests: maximum requests allowed per window (e.g., 5)
 // windowDuration: time window duration (e.g., 1 minute)
 func NewRateLimiter(maxRequests int, windowDuration time.Duration) *RateLimiter {
-	rl := &RateLimiter{
-		Requests:       make(map[string]int),
-		MaxRequests:    maxRequests,
-		WindowDuration: windowDuration,
-	}
-	
-	// Start the background goroutine that resets request counts every minute
-	rl.startResetTicker()
-	
-	return rl
+	return NewRateLimiterWithTTL(maxRequests, windowDuration, defaultTTL)
 }
 
-// startResetTicker starts a goroutine that periodically resets the request counts
-// This runs in the background and clears the Requests map every time window
-// This is similar to how services like Stripe/OpenAI reset their rate limits
-func (rl *RateLimiter) startResetTicker() {
-	ticker := time.NewTicker(rl.WindowDuration)
-	
-	go func() {
-		for range ticker.C {
-			// Lock the mutex before modifying the map
-			rl.Mutex.Lock()
-			
-			// Clear all request counts - this effectively resets the rate limit window
-			// All IPs get a fresh start for the next minute
-			rl.Requests = make(map[string]int)
-			
-			// Unlock after modification
-			rl.Mutex.Unlock()
-		}
-	}()
+// NewRateLimiterWithTTL is like NewRateLimiter but lets callers configure how
+// long an idle IP is tracked before its InMemoryStore entry is evicted.
+func NewRateLimiterWithTTL(maxRequests int, windowDuration, ttl time.Duration) *RateLimiter {
+	return NewRateLimiterWithStore(NewInMemoryStoreWithTTL(maxRequests, windowDuration, ttl), maxRequests, windowDuration)
 }
 
-// AllowRequest checks if a request from the given IP should be allowed
-// Returns true if the request is allowed, false if rate limit is exceeded
-func (rl *RateLimiter) AllowRequest(ip string) bool {
-	// Lock the mutex to ensure thread-safe access
-	rl.Mutex.Lock()
-	defer rl.Mutex.Unlock()
-	
-	// Get current request count for this IP (defaults to 0 if IP not in map)
-	currentCount := rl.Requests[ip]
-	
-	// Check if the IP has exceeded the maximum requests
-	if currentCount >= rl.MaxRequests {
-		// Rate limit exceeded - don't increment, just return false
-		return false
+// NewRateLimiterWithStore creates a RateLimiter that delegates counting to
+// store, so the limit for each IP is shared across every RateLimiter pointed
+// at the same backend (e.g. multiple API instances behind a load balancer,
+// via RedisStore).
+func NewRateLimiterWithStore(store Store, maxRequests int, windowDuration time.Duration) *RateLimiter {
+	return &RateLimiter{
+		store:          store,
+		windowDuration: windowDuration,
+		windowState:    make(map[string]windowState),
+		rate:           rate.Limit(float64(maxRequests) / windowDuration.Seconds()),
+		burst:          maxRequests,
 	}
-	
-	// Increment the request count for this IP
-	rl.Requests[ip] = currentCount + 1
-	
-	// Request is allowed
-	return true
 }
 
-// GetRemainingRequests returns the number of remaining requests for an IP
-// This is useful for debugging or returning rate limit headers
-func (rl *RateLimiter) GetRemainingRequests(ip string) int {
+// AllowRequest checks if a request from the given IP should be allowed.
+// Returns true if the request is allowed, false if rate limit is exceeded.
+func (rl *RateLimiter) AllowRequest(ip string) bool {
+	count, resetAt, err := rl.store.Increment(ip, rl.windowDuration)
+	if err != nil {
+		// Fail open: a store outage shouldn't take down the whole API.
+		return true
+	}
+
 	rl.Mutex.Lock()
-	defer rl.Mutex.Unlock()
-	
-	currentCount := rl.Requests[ip]
-	remaining := rl.MaxRequests - currentCount
-	
+	rl.windowState[ip] = windowState{count: count, resetAt: resetAt}
+	rl.Mutex.Unlock()
+
+	return count <= rl.burst
+}
+
+// Limit returns the configured requests-per-window limit, for use in
+// rate-limit response headers.
+func (rl *RateLimiter) Limit() int {
+	return rl.burst
+}
+
+// Remaining returns the number of requests ip currently has available,
+// rounded down to the nearest whole request.
+func (rl *RateLimiter) Remaining(ip string) int {
+	remaining := rl.burst - rl.state(ip).count
 	if remaining < 0 {
 		return 0
 	}
-	
 	return remaining
 }
 
+// ResetAt returns the time at which ip's limit will be reset.
+func (rl *RateLimiter) ResetAt(ip string) time.Time {
+	if state := rl.state(ip); !state.resetAt.IsZero() {
+		return state.resetAt
+	}
+	return time.Now().Add(rl.windowDuration)
+}
+
+// state returns the last known store-reported windowState for ip.
+func (rl *RateLimiter) state(ip string) windowState {
+	rl.Mutex.Lock()
+	defer rl.Mutex.Unlock()
+
+	return rl.windowState[ip]
+}
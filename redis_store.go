@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// incrementScript atomically increments the counter for a key and arms its
+// expiry the first time it's created, so the INCR and the EXPIRE can't race
+// across concurrent requests from different instances.
+var incrementScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+local ttl = redis.call("PTTL", KEYS[1])
+return {count, ttl}
+`)
+
+// RedisStore implements Store on top of Redis, so the request count for a
+// key is shared across every API instance behind a load balancer instead of
+// living in a single process's memory.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore wraps an existing Redis client for use as a Store.
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Increment records one request for key in Redis, using a Lua script so the
+// increment and the window expiry are applied atomically.
+func (s *RedisStore) Increment(key string, window time.Duration) (int, time.Time, error) {
+	res, err := incrementScript.Run(context.Background(), s.client, []string{key}, window.Milliseconds()).Result()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	values := res.([]interface{})
+	count := int(values[0].(int64))
+	ttl := time.Duration(values[1].(int64)) * time.Millisecond
+
+	return count, time.Now().Add(ttl), nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedisStore(t *testing.T) *RedisStore {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewRedisStore(client)
+}
+
+func TestRedisStoreIncrement(t *testing.T) {
+	store := newTestRedisStore(t)
+	window := time.Minute
+
+	for i := 1; i <= 3; i++ {
+		count, resetAt, err := store.Increment("1.2.3.4", window)
+		if err != nil {
+			t.Fatalf("Increment returned error: %v", err)
+		}
+		if count != i {
+			t.Errorf("expected count %d, got %d", i, count)
+		}
+		if resetAt.Before(time.Now()) {
+			t.Errorf("expected resetAt in the future, got %v", resetAt)
+		}
+	}
+}
+
+func TestRedisStoreIsolatesKeys(t *testing.T) {
+	store := newTestRedisStore(t)
+	window := time.Minute
+
+	count, _, err := store.Increment("1.1.1.1", window)
+	if err != nil {
+		t.Fatalf("Increment returned error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected count 1 for first key, got %d", count)
+	}
+
+	count, _, err = store.Increment("2.2.2.2", window)
+	if err != nil {
+		t.Fatalf("Increment returned error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected count 1 for second key, got %d", count)
+	}
+}
+
+func TestRateLimiterWithStoreEnforcesLimit(t *testing.T) {
+	store := newTestRedisStore(t)
+	rl := NewRateLimiterWithStore(store, 3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if !rl.AllowRequest("9.9.9.9") {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+
+	if rl.AllowRequest("9.9.9.9") {
+		t.Error("expected 4th request to be denied")
+	}
+}
+
+func TestSlidingWindowLimiterWithRedisStoreEnforcesLimit(t *testing.T) {
+	store := newTestRedisStore(t)
+	sw := NewSlidingWindowLimiter(store, 3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if !sw.AllowRequest("9.9.9.9") {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+
+	if sw.AllowRequest("9.9.9.9") {
+		t.Error("expected 4th request to be denied")
+	}
+}
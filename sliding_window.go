@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// bucketState is the minimal per-key bookkeeping SlidingWindowLimiter keeps
+// locally: just enough to weight the previous bucket's contribution. The
+// counts themselves live in Store, so the limit for each key is shared
+// across every SlidingWindowLimiter pointed at the same backend, the same as
+// RateLimiter.
+type bucketState struct {
+	index         int64
+	lastCurrCount int
+	prevCount     int
+}
+
+// SlidingWindowLimiter approximates a sliding window over two adjacent
+// fixed-size buckets of length Window, weighting the previous bucket's count
+// by how much of it still overlaps the sliding window. This smooths out the
+// boundary-burst problem of a plain fixed-window counter while keeping O(1)
+// memory per key, the same approach Cloudflare uses (see the theopenlane
+// rate-limiter README).
+type SlidingWindowLimiter struct {
+	Mutex sync.Mutex
+
+	store       Store
+	buckets     map[string]*bucketState
+	maxRequests int
+	window      time.Duration
+	now         func() time.Time
+}
+
+// NewSlidingWindowLimiter creates a SlidingWindowLimiter allowing maxRequests
+// per window, with counts kept in store so multiple instances pointed at the
+// same backend (e.g. RedisStore) enforce one shared limit, same as
+// NewRateLimiterWithStore. For a local, single-instance store use
+// NewInMemoryWindowStore, not InMemoryStore - InMemoryStore's token bucket
+// lets a count refill over time, which understates usage within a bucket
+// instead of counting it monotonically.
+func NewSlidingWindowLimiter(store Store, maxRequests int, window time.Duration) *SlidingWindowLimiter {
+	return newSlidingWindowLimiter(store, maxRequests, window, time.Now)
+}
+
+// newSlidingWindowLimiter is the internal constructor that lets tests inject
+// a deterministic clock.
+func newSlidingWindowLimiter(store Store, maxRequests int, window time.Duration, now func() time.Time) *SlidingWindowLimiter {
+	return &SlidingWindowLimiter{
+		store:       store,
+		buckets:     make(map[string]*bucketState),
+		maxRequests: maxRequests,
+		window:      window,
+		now:         now,
+	}
+}
+
+// AllowRequest checks whether a request from ip should be allowed under the
+// estimated sliding-window count.
+func (sw *SlidingWindowLimiter) AllowRequest(ip string) bool {
+	now := sw.now()
+	index := sw.bucketIndex(now)
+
+	prevCount := sw.rollBucket(ip, index)
+
+	currCount, _, err := sw.store.Increment(sw.bucketKey(ip, index), sw.window)
+	if err != nil {
+		// Fail open: a store outage shouldn't take down the whole API.
+		return true
+	}
+
+	sw.Mutex.Lock()
+	sw.buckets[ip].lastCurrCount = currCount
+	sw.Mutex.Unlock()
+
+	estimate := float64(currCount) + float64(prevCount)*sw.weight(now, index)
+
+	return estimate <= float64(sw.maxRequests)
+}
+
+// Limit returns the configured requests-per-window limit, for use in
+// rate-limit response headers.
+func (sw *SlidingWindowLimiter) Limit() int {
+	return sw.maxRequests
+}
+
+// Remaining returns ip's estimated remaining requests in the current sliding
+// window, without consuming one.
+func (sw *SlidingWindowLimiter) Remaining(ip string) int {
+	now := sw.now()
+	index := sw.bucketIndex(now)
+
+	sw.Mutex.Lock()
+	b, ok := sw.buckets[ip]
+	prevCount, currCount := 0, 0
+	if ok {
+		prevCount, currCount = b.prevCount, b.lastCurrCount
+		if b.index != index {
+			if index-b.index == 1 {
+				prevCount = currCount
+			} else {
+				prevCount = 0
+			}
+			currCount = 0
+		}
+	}
+	sw.Mutex.Unlock()
+
+	remaining := sw.maxRequests - int(float64(currCount)+float64(prevCount)*sw.weight(now, index))
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// ResetAt returns the time at which the current bucket ends and the
+// previous bucket's contribution to ip's estimate starts to drop off.
+func (sw *SlidingWindowLimiter) ResetAt(ip string) time.Time {
+	now := sw.now()
+	return sw.bucketStart(sw.bucketIndex(now)).Add(sw.window)
+}
+
+// rollBucket returns ip's previous-bucket count, advancing its local bucket
+// index to index if this is a new bucket. When index has moved forward by
+// exactly one bucket, the outgoing bucket's last known count carries over as
+// prevCount; any larger gap means the sliding window has fully elapsed, so
+// prevCount resets to zero.
+func (sw *SlidingWindowLimiter) rollBucket(ip string, index int64) int {
+	sw.Mutex.Lock()
+	defer sw.Mutex.Unlock()
+
+	b, ok := sw.buckets[ip]
+	if !ok {
+		b = &bucketState{index: index}
+		sw.buckets[ip] = b
+	}
+
+	if b.index != index {
+		if index-b.index == 1 {
+			b.prevCount = b.lastCurrCount
+		} else {
+			b.prevCount = 0
+		}
+		b.index = index
+		b.lastCurrCount = 0
+	}
+
+	return b.prevCount
+}
+
+// bucketIndex returns the index of the fixed-size bucket t falls into.
+func (sw *SlidingWindowLimiter) bucketIndex(t time.Time) int64 {
+	return t.UnixNano() / int64(sw.window)
+}
+
+// bucketStart returns the start time of the bucket at index.
+func (sw *SlidingWindowLimiter) bucketStart(index int64) time.Time {
+	return time.Unix(0, index*int64(sw.window))
+}
+
+// bucketKey returns the Store key for ip's bucket at index.
+func (sw *SlidingWindowLimiter) bucketKey(ip string, index int64) string {
+	return fmt.Sprintf("%s|sw|%d", ip, index)
+}
+
+// weight returns how much of the previous bucket's count still overlaps the
+// sliding window at t, given t falls in the bucket at index.
+func (sw *SlidingWindowLimiter) weight(t time.Time, index int64) float64 {
+	elapsed := t.Sub(sw.bucketStart(index))
+	return float64(sw.window-elapsed) / float64(sw.window)
+}
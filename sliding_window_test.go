@@ -0,0 +1,169 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually-advanced clock for driving SlidingWindowLimiter
+// deterministically in tests.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+// fakeStore is a minimal Store that never expires keys, so tests can drive
+// SlidingWindowLimiter's bucket math deterministically without also faking
+// out a backend's own TTL/refill logic.
+type fakeStore struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{counts: make(map[string]int)}
+}
+
+func (s *fakeStore) Increment(key string, _ time.Duration) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts[key]++
+
+	return s.counts[key], time.Time{}, nil
+}
+
+func TestSlidingWindowLimiterAllowsUpToLimit(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	sw := newSlidingWindowLimiter(newFakeStore(), 3, time.Minute, clock.now)
+
+	for i := 0; i < 3; i++ {
+		if !sw.AllowRequest("1.2.3.4") {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+
+	if sw.AllowRequest("1.2.3.4") {
+		t.Error("expected 4th request within the same window to be denied")
+	}
+}
+
+func TestSlidingWindowLimiterSmoothsBoundary(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	sw := newSlidingWindowLimiter(newFakeStore(), 4, time.Minute, clock.now)
+
+	// Use up the whole budget right at the end of the first window.
+	clock.advance(59 * time.Second)
+	for i := 0; i < 4; i++ {
+		if !sw.AllowRequest("1.2.3.4") {
+			t.Fatalf("expected request %d in window 1 to be allowed", i+1)
+		}
+	}
+
+	// One second into window 2, the estimate still carries ~98% of window
+	// 1's count, so the bucket should read as effectively full.
+	clock.advance(2 * time.Second)
+	if sw.AllowRequest("1.2.3.4") {
+		t.Error("expected request just after the boundary to be denied")
+	}
+
+	// Halfway through window 2, window 1's contribution has decayed enough
+	// to allow a couple more requests.
+	clock.advance(29 * time.Second)
+	allowed := 0
+	for i := 0; i < 4; i++ {
+		if sw.AllowRequest("1.2.3.4") {
+			allowed++
+		}
+	}
+	if allowed == 0 {
+		t.Error("expected at least one request to be allowed as window 1 decays")
+	}
+	if allowed == 4 {
+		t.Error("expected window 1's carried-over count to still limit window 2")
+	}
+}
+
+func TestSlidingWindowLimiterResetsAfterTwoFullWindows(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	sw := newSlidingWindowLimiter(newFakeStore(), 2, time.Minute, clock.now)
+
+	if !sw.AllowRequest("1.2.3.4") || !sw.AllowRequest("1.2.3.4") {
+		t.Fatal("expected both requests in window 1 to be allowed")
+	}
+
+	// Skip straight past window 2 into window 3; window 1's count should no
+	// longer carry over at all.
+	clock.advance(3 * time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if !sw.AllowRequest("1.2.3.4") {
+			t.Fatalf("expected request %d after the gap to be allowed", i+1)
+		}
+	}
+}
+
+func TestSlidingWindowLimiterIsolatesKeys(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	sw := newSlidingWindowLimiter(newFakeStore(), 1, time.Minute, clock.now)
+
+	if !sw.AllowRequest("1.1.1.1") {
+		t.Fatal("expected first request from 1.1.1.1 to be allowed")
+	}
+	if !sw.AllowRequest("2.2.2.2") {
+		t.Fatal("expected first request from a different key to be allowed")
+	}
+	if sw.AllowRequest("1.1.1.1") {
+		t.Error("expected second request from 1.1.1.1 to be denied")
+	}
+}
+
+func TestSlidingWindowLimiterSharesCountsAcrossInstancesViaStore(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	store := newFakeStore()
+
+	swA := newSlidingWindowLimiter(store, 2, time.Minute, clock.now)
+	swB := newSlidingWindowLimiter(store, 2, time.Minute, clock.now)
+
+	if !swA.AllowRequest("9.9.9.9") {
+		t.Fatal("expected first request via instance A to be allowed")
+	}
+	if !swB.AllowRequest("9.9.9.9") {
+		t.Fatal("expected second request via instance B to be allowed")
+	}
+	if swA.AllowRequest("9.9.9.9") {
+		t.Error("expected the shared limit to be exhausted across instances")
+	}
+}
+
+// TestSlidingWindowLimiterWithInMemoryWindowStoreDeniesSpacedOutRequests
+// guards against a real regression: InMemoryStore's token bucket lets a
+// count "refill" as time passes within the same bucket, so a limiter built
+// on it would wrongly re-admit requests spaced a few seconds apart well
+// before the window actually rolls over. InMemoryWindowStore must not do
+// that.
+func TestSlidingWindowLimiterWithInMemoryWindowStoreDeniesSpacedOutRequests(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	sw := newSlidingWindowLimiter(NewInMemoryWindowStore(), 4, time.Minute, clock.now)
+
+	for i := 0; i < 4; i++ {
+		if !sw.AllowRequest("1.2.3.4") {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+
+	// Still well inside the same 1-minute bucket; a monotonic counter must
+	// not have "refilled" any capacity back.
+	clock.advance(16 * time.Second)
+	if sw.AllowRequest("1.2.3.4") {
+		t.Error("expected a request later in the same bucket to stay denied, not be re-admitted by a refilling store")
+	}
+}
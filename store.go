@@ -0,0 +1,12 @@
+package main
+
+import "time"
+
+// Store abstracts the counter backend used for distributed rate limiting, so
+// the same limiting logic can run against a process-local map or a shared
+// backend like Redis when multiple API instances sit behind a load balancer.
+type Store interface {
+	// Increment records one request for key within window, returning the
+	// updated count for the current window and the time it resets at.
+	Increment(key string, window time.Duration) (count int, resetAt time.Time, err error)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// windowEntry tracks a single key's count for its current fixed window.
+type windowEntry struct {
+	count    int
+	resetAt  time.Time
+	lastSeen time.Time
+}
+
+// InMemoryWindowStore is a process-local Store backend that counts requests
+// as a true fixed-window counter: a key's count increments monotonically
+// until window has elapsed since the key was first seen, at which point it
+// resets. Unlike InMemoryStore (a token bucket, right for RateLimiter's
+// continuous rate but wrong here), this never lets a count "refill" back
+// down over time, so it's the correct in-memory backend for
+// SlidingWindowLimiter, whose keys are already scoped to one bucket each and
+// need a real monotonic count within that bucket's lifetime. A sweeper
+// evicts keys that have gone idle past ttl so the map doesn't grow
+// unbounded.
+type InMemoryWindowStore struct {
+	Mutex sync.Mutex
+
+	entries map[string]*windowEntry
+	ttl     time.Duration
+}
+
+// NewInMemoryWindowStore creates an InMemoryWindowStore using the default
+// idle-eviction TTL.
+func NewInMemoryWindowStore() *InMemoryWindowStore {
+	return NewInMemoryWindowStoreWithTTL(defaultTTL)
+}
+
+// NewInMemoryWindowStoreWithTTL is like NewInMemoryWindowStore but lets
+// callers configure how long an idle key is tracked before the sweeper
+// evicts it.
+func NewInMemoryWindowStoreWithTTL(ttl time.Duration) *InMemoryWindowStore {
+	s := &InMemoryWindowStore{
+		entries: make(map[string]*windowEntry),
+		ttl:     ttl,
+	}
+
+	s.startSweeper()
+
+	return s
+}
+
+// Increment records one request for key, starting a fresh window if none is
+// active or the current one has expired.
+func (s *InMemoryWindowStore) Increment(key string, window time.Duration) (int, time.Time, error) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	now := time.Now()
+
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.resetAt) {
+		entry = &windowEntry{resetAt: now.Add(window)}
+		s.entries[key] = entry
+	}
+
+	entry.count++
+	entry.lastSeen = now
+
+	return entry.count, entry.resetAt, nil
+}
+
+// startSweeper runs in the background, evicting keys that have been idle
+// longer than ttl so the map doesn't grow unbounded.
+func (s *InMemoryWindowStore) startSweeper() {
+	ticker := time.NewTicker(sweepInterval)
+
+	go func() {
+		for range ticker.C {
+			cutoff := time.Now().Add(-s.ttl)
+
+			s.Mutex.Lock()
+			for key, e := range s.entries {
+				if e.lastSeen.Before(cutoff) {
+					delete(s.entries, key)
+				}
+			}
+			s.Mutex.Unlock()
+		}
+	}()
+}